@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// authScheme is the Authorization header scheme for HMAC-signed requests,
+// modeled on AWS's "AWS4-HMAC-SHA256".
+const authScheme = "KBAN1-HMAC-SHA256"
+
+// maxClockSkew bounds how far X-Kban-Date may drift from the server's
+// clock before a signed request is rejected, to limit replay of old
+// signatures.
+const maxClockSkew = 5 * time.Minute
+
+// bearerTokenTTL is how long a token minted by POST /api/auth/token stays
+// valid.
+const bearerTokenTTL = 1 * time.Hour
+
+type contextKey string
+
+const contextKeyAccessKey contextKey = "kban-access-key"
+
+// accessKeyFromContext returns the AccessKey that authenticated the current
+// request, as set by authMiddleware.
+func accessKeyFromContext(ctx context.Context) (*AccessKey, bool) {
+	key, ok := ctx.Value(contextKeyAccessKey).(*AccessKey)
+	return key, ok
+}
+
+// stringToSign builds the canonical string an HMAC-signed request must
+// sign: METHOD\nPATH\nSHA256(body)\nX-Kban-Date.
+func stringToSign(method, path string, body []byte, date string) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{method, path, hex.EncodeToString(bodyHash[:]), date}, "\n")
+}
+
+// signRequest computes the hex HMAC-SHA256 signature a client would send
+// for the given request, using secretKey. Exposed for use by CLI/test
+// helpers that need to produce a valid Authorization header.
+func signRequest(secretKey, method, path string, body []byte, date string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(stringToSign(method, path, body, date)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseSignedAuthorization extracts the access key ID and signature from an
+// "Authorization: KBAN1-HMAC-SHA256 Credential=<AK>, Signature=<hex>"
+// header.
+func parseSignedAuthorization(header string) (accessKeyID, signature string, err error) {
+	scheme, rest, ok := strings.Cut(header, " ")
+	if !ok || scheme != authScheme {
+		return "", "", errors.New("unsupported authorization scheme")
+	}
+	for _, part := range strings.Split(rest, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "Credential":
+			accessKeyID = v
+		case "Signature":
+			signature = v
+		}
+	}
+	if accessKeyID == "" || signature == "" {
+		return "", "", errors.New("missing Credential or Signature")
+	}
+	return accessKeyID, signature, nil
+}
+
+// requiredRole returns the minimum role a request needs: admin for key
+// management, write for mutations, read for everything else.
+func requiredRole(r *http.Request) string {
+	if strings.HasPrefix(r.URL.Path, "/api/keys") {
+		return "admin"
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return "read"
+	}
+	return "write"
+}
+
+// authMiddleware gates every request under it behind either an HMAC-signed
+// Authorization header or a bearer token previously issued by
+// POST /api/auth/token, and enforces the role the route requires.
+func authMiddleware(keys KeyStore, tokens *tokenIssuer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := authenticate(r, keys, tokens)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unauthorized: %v", err), http.StatusUnauthorized)
+				return
+			}
+			need := requiredRole(r)
+			if r.URL.Path == "/api/auth/token" {
+				need = "read"
+			}
+			if !key.Authorizes(need) {
+				http.Error(w, fmt.Sprintf("forbidden: %s role required", need), http.StatusForbidden)
+				return
+			}
+			ctx := context.WithValue(r.Context(), contextKeyAccessKey, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticate verifies the request's Authorization header, either an
+// HMAC-signed credential or a bearer token, and returns the AccessKey it
+// resolves to.
+func authenticate(r *http.Request, keys KeyStore, tokens *tokenIssuer) (*AccessKey, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, errors.New("missing Authorization header")
+	}
+	if strings.HasPrefix(header, "Bearer ") {
+		token := strings.TrimPrefix(header, "Bearer ")
+		return tokens.validate(token)
+	}
+
+	accessKeyID, signature, err := parseSignedAuthorization(header)
+	if err != nil {
+		return nil, err
+	}
+	date := r.Header.Get("X-Kban-Date")
+	if date == "" {
+		return nil, errors.New("missing X-Kban-Date header")
+	}
+	ts, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid X-Kban-Date: %w", err)
+	}
+	if skew := time.Since(ts); skew > maxClockSkew || skew < -maxClockSkew {
+		return nil, errors.New("request date outside allowed clock skew")
+	}
+
+	key, err := keys.Get(r.Context(), accessKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading body: %w", err)
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+	}
+	expected := signRequest(key.SecretKey, r.Method, r.URL.Path, body, date)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+		return nil, errors.New("signature mismatch")
+	}
+	return key, nil
+}
+
+// tokenIssuer mints and validates short-lived bearer tokens scoped to the
+// roles of the access key that requested them, so a client can authenticate
+// a burst of requests without re-signing each one.
+type tokenIssuer struct {
+	mu     sync.Mutex
+	tokens map[string]issuedToken
+}
+
+type issuedToken struct {
+	key       AccessKey
+	expiresAt time.Time
+}
+
+func newTokenIssuer() *tokenIssuer {
+	return &tokenIssuer{tokens: make(map[string]issuedToken)}
+}
+
+func (t *tokenIssuer) issue(key AccessKey) (string, error) {
+	token, err := randomBase32(20)
+	if err != nil {
+		return "", err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tokens[token] = issuedToken{key: key, expiresAt: time.Now().Add(bearerTokenTTL)}
+	return token, nil
+}
+
+func (t *tokenIssuer) validate(token string) (*AccessKey, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.tokens[token]
+	if !ok {
+		return nil, errors.New("unknown or expired bearer token")
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(t.tokens, token)
+		return nil, errors.New("unknown or expired bearer token")
+	}
+	key := entry.key
+	return &key, nil
+}
+
+// randomBase32 returns n random bytes, base32-encoded without padding.
+func randomBase32(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf), nil
+}
+
+// generateAccessKey creates a new AccessKey with a random 8-byte ID and
+// 32-byte secret, scoped to roles. Used by "kban keygen".
+func generateAccessKey(roles []string) (AccessKey, error) {
+	id, err := randomBase32(8)
+	if err != nil {
+		return AccessKey{}, err
+	}
+	secret, err := randomBase32(32)
+	if err != nil {
+		return AccessKey{}, err
+	}
+	return AccessKey{
+		AccessKeyID: id,
+		SecretKey:   secret,
+		Roles:       roles,
+		CreatedAt:   time.Now(),
+	}, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAuthKeys(t *testing.T, roles ...string) (KeyStore, AccessKey) {
+	t.Helper()
+	keys, err := newMemoryKeyStore(Config{})
+	if err != nil {
+		t.Fatalf("newMemoryKeyStore: %v", err)
+	}
+	key := AccessKey{AccessKeyID: "AKID", SecretKey: "s3cr3t", Roles: roles}
+	if err := keys.Save(context.Background(), key); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return keys, key
+}
+
+func signedRequest(t *testing.T, key AccessKey, method, path string, date time.Time) *http.Request {
+	t.Helper()
+	dateStr := date.Format(time.RFC3339)
+	sig := signRequest(key.SecretKey, method, path, nil, dateStr)
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Kban-Date", dateStr)
+	req.Header.Set("Authorization", authScheme+" Credential="+key.AccessKeyID+", Signature="+sig)
+	return req
+}
+
+func TestAuthenticateValidSignature(t *testing.T) {
+	keys, key := newTestAuthKeys(t, "read")
+	tokens := newTokenIssuer()
+	req := signedRequest(t, key, http.MethodGet, "/api/boards", time.Now())
+
+	got, err := authenticate(req, keys, tokens)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if got.AccessKeyID != key.AccessKeyID {
+		t.Fatalf("AccessKeyID = %q, want %q", got.AccessKeyID, key.AccessKeyID)
+	}
+}
+
+func TestAuthenticateRejectsBadSignature(t *testing.T) {
+	keys, key := newTestAuthKeys(t, "read")
+	tokens := newTokenIssuer()
+	req := signedRequest(t, key, http.MethodGet, "/api/boards", time.Now())
+	req.Header.Set("Authorization", strings.Replace(req.Header.Get("Authorization"), "Signature=", "Signature=deadbeef", 1))
+
+	if _, err := authenticate(req, keys, tokens); err == nil {
+		t.Fatal("authenticate succeeded with a tampered signature")
+	}
+}
+
+func TestAuthenticateRejectsTamperedPath(t *testing.T) {
+	keys, key := newTestAuthKeys(t, "read")
+	tokens := newTokenIssuer()
+	req := signedRequest(t, key, http.MethodGet, "/api/boards/mine", time.Now())
+	req.URL.Path = "/api/boards/someone-elses"
+
+	if _, err := authenticate(req, keys, tokens); err == nil {
+		t.Fatal("authenticate succeeded after the signed path was swapped")
+	}
+}
+
+func TestAuthenticateRejectsClockSkew(t *testing.T) {
+	keys, key := newTestAuthKeys(t, "read")
+	tokens := newTokenIssuer()
+	req := signedRequest(t, key, http.MethodGet, "/api/boards", time.Now().Add(-maxClockSkew-time.Minute))
+
+	if _, err := authenticate(req, keys, tokens); err == nil {
+		t.Fatal("authenticate succeeded with a request date outside the allowed clock skew")
+	}
+}
+
+func TestAuthenticateAcceptsWithinClockSkew(t *testing.T) {
+	keys, key := newTestAuthKeys(t, "read")
+	tokens := newTokenIssuer()
+	req := signedRequest(t, key, http.MethodGet, "/api/boards", time.Now().Add(-maxClockSkew+time.Minute))
+
+	if _, err := authenticate(req, keys, tokens); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+}
+
+func TestAuthenticateBearerToken(t *testing.T) {
+	keys, key := newTestAuthKeys(t, "read")
+	tokens := newTokenIssuer()
+	token, err := tokens.issue(key)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/boards", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	got, err := authenticate(req, keys, tokens)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if got.AccessKeyID != key.AccessKeyID {
+		t.Fatalf("AccessKeyID = %q, want %q", got.AccessKeyID, key.AccessKeyID)
+	}
+}
+
+func TestAuthenticateRejectsUnknownBearerToken(t *testing.T) {
+	keys, _ := newTestAuthKeys(t, "read")
+	tokens := newTokenIssuer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/boards", nil)
+	req.Header.Set("Authorization", "Bearer not-a-real-token")
+
+	if _, err := authenticate(req, keys, tokens); err == nil {
+		t.Fatal("authenticate succeeded with an unknown bearer token")
+	}
+}
+
+func TestAccessKeyAuthorizesRoleHierarchy(t *testing.T) {
+	cases := []struct {
+		roles []string
+		need  string
+		want  bool
+	}{
+		{[]string{"read"}, "read", true},
+		{[]string{"read"}, "write", false},
+		{[]string{"write"}, "read", true},
+		{[]string{"write"}, "write", true},
+		{[]string{"write"}, "admin", false},
+		{[]string{"admin"}, "write", true},
+		{[]string{"admin"}, "admin", true},
+	}
+	for _, c := range cases {
+		key := AccessKey{Roles: c.roles}
+		if got := key.Authorizes(c.need); got != c.want {
+			t.Errorf("Authorizes(%q) with roles %v = %v, want %v", c.need, c.roles, got, c.want)
+		}
+	}
+}
+
+func TestRequiredRole(t *testing.T) {
+	cases := []struct {
+		method, path string
+		want         string
+	}{
+		{http.MethodGet, "/api/boards", "read"},
+		{http.MethodPost, "/api/boards", "write"},
+		{http.MethodGet, "/api/keys", "admin"},
+		{http.MethodDelete, "/api/keys/AKID", "admin"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if got := requiredRole(req); got != c.want {
+			t.Errorf("requiredRole(%s %s) = %q, want %q", c.method, c.path, got, c.want)
+		}
+	}
+}
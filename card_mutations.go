@@ -0,0 +1,117 @@
+package main
+
+// cloneBoard returns a deep-enough copy of board so a mutation function can
+// modify the copy freely without affecting the snapshot the caller loaded.
+func cloneBoard(board *Board) *Board {
+	cards := make([]Card, len(board.Cards))
+	copy(cards, board.Cards)
+	columns := make([]string, len(board.Columns))
+	copy(columns, board.Columns)
+	return &Board{ID: board.ID, Name: board.Name, Columns: columns, Cards: cards}
+}
+
+// applyCardCreate returns a new board with card appended to the end of its
+// target column, and the card as actually stored (with Position assigned).
+// It is pure so it can be replayed against a fresh board snapshot if the
+// save underneath it loses a race with another writer.
+func applyCardCreate(board *Board, card Card) (*Board, Card) {
+	newBoard := cloneBoard(board)
+	maxPos := -1
+	for _, c := range newBoard.Cards {
+		if c.Status == card.Status && c.Position > maxPos {
+			maxPos = c.Position
+		}
+	}
+	card.Position = maxPos + 1
+	newBoard.Cards = append(newBoard.Cards, card)
+	return newBoard, card
+}
+
+// applyCardUpdate returns a new board with the card identified by id
+// updated to match update, reordering the positions of the other cards in
+// its target column. It returns ErrCardNotFound if id doesn't exist. It is
+// pure so it can be replayed against a fresh board snapshot on retry.
+func applyCardUpdate(board *Board, id string, update Card) (*Board, error) {
+	newBoard := cloneBoard(board)
+	var oldStatus string
+	var oldPosition int
+	updated := false
+	for i, c := range newBoard.Cards {
+		if c.ID == id {
+			oldStatus = c.Status
+			oldPosition = c.Position
+			newBoard.Cards[i].Title = update.Title
+			newBoard.Cards[i].Description = update.Description
+			newBoard.Cards[i].Status = update.Status
+			newBoard.Cards[i].Position = update.Position
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		return nil, ErrCardNotFound
+	}
+
+	// Réordonner les positions dans la colonne si la position a changé
+	if oldStatus != update.Status || oldPosition != update.Position {
+		moveIdx := findCardIndex(newBoard.Cards, id)
+		if moveIdx != -1 {
+			// Build list of other cards in the target column (exclude moving card)
+			others := []Card{}
+			for _, cc := range newBoard.Cards {
+				if cc.ID == id {
+					continue
+				}
+				if cc.Status == update.Status {
+					others = append(others, cc)
+				}
+			}
+			// Clamp requested position
+			pos := update.Position
+			if pos < 0 {
+				pos = 0
+			}
+			if pos > len(others) {
+				pos = len(others)
+			}
+			// Build new order for the column: insert moving card at pos
+			movingCard := newBoard.Cards[moveIdx]
+			movingCard.Status = update.Status
+			newOrder := make([]Card, 0, len(others)+1)
+			newOrder = append(newOrder, others[:pos]...)
+			newOrder = append(newOrder, movingCard)
+			if pos < len(others) {
+				newOrder = append(newOrder, others[pos:]...)
+			}
+			// Reassign positions for cards in this column
+			for i, nc := range newOrder {
+				for j := range newBoard.Cards {
+					if newBoard.Cards[j].ID == nc.ID {
+						newBoard.Cards[j].Position = i
+						newBoard.Cards[j].Status = update.Status
+					}
+				}
+			}
+		}
+	}
+	return newBoard, nil
+}
+
+// applyCardDelete returns a new board with the card identified by id
+// removed. It returns ErrCardNotFound if id doesn't exist. It is pure so it
+// can be replayed against a fresh board snapshot on retry.
+func applyCardDelete(board *Board, id string) (*Board, error) {
+	newBoard := cloneBoard(board)
+	countBefore := len(newBoard.Cards)
+	newCards := make([]Card, 0, countBefore)
+	for _, c := range newBoard.Cards {
+		if c.ID != id {
+			newCards = append(newCards, c)
+		}
+	}
+	if len(newCards) == countBefore {
+		return nil, ErrCardNotFound
+	}
+	newBoard.Cards = newCards
+	return newBoard, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestApplyCardCreateAssignsNextPosition(t *testing.T) {
+	board := newEmptyBoard("b1")
+	board.Cards = []Card{{ID: "c1", Status: "ToDo", Position: 0}, {ID: "c2", Status: "Doing", Position: 0}}
+
+	newBoard, created := applyCardCreate(board, Card{ID: "c3", Status: "ToDo"})
+
+	if created.Position != 1 {
+		t.Fatalf("created.Position = %d, want 1", created.Position)
+	}
+	if len(newBoard.Cards) != 3 {
+		t.Fatalf("len(newBoard.Cards) = %d, want 3", len(newBoard.Cards))
+	}
+	if len(board.Cards) != 2 {
+		t.Fatalf("original board.Cards mutated: %+v", board.Cards)
+	}
+}
+
+func TestApplyCardUpdateMovesBetweenColumns(t *testing.T) {
+	board := newEmptyBoard("b1")
+	board.Cards = []Card{
+		{ID: "c1", Status: "ToDo", Position: 0},
+		{ID: "c2", Status: "Doing", Position: 0},
+		{ID: "c3", Status: "Doing", Position: 1},
+	}
+
+	newBoard, err := applyCardUpdate(board, "c1", Card{Title: "moved", Status: "Doing", Position: 1})
+	if err != nil {
+		t.Fatalf("applyCardUpdate: %v", err)
+	}
+
+	byID := make(map[string]Card, len(newBoard.Cards))
+	for _, c := range newBoard.Cards {
+		byID[c.ID] = c
+	}
+	if byID["c1"].Status != "Doing" || byID["c1"].Position != 1 {
+		t.Fatalf("c1 = %+v, want Status=Doing Position=1", byID["c1"])
+	}
+	if byID["c2"].Position != 0 || byID["c3"].Position != 2 {
+		t.Fatalf("Doing column positions = c2:%d c3:%d, want 0 and 2", byID["c2"].Position, byID["c3"].Position)
+	}
+}
+
+func TestApplyCardUpdateUnknownID(t *testing.T) {
+	board := newEmptyBoard("b1")
+	if _, err := applyCardUpdate(board, "missing", Card{}); err != ErrCardNotFound {
+		t.Fatalf("err = %v, want ErrCardNotFound", err)
+	}
+}
+
+func TestApplyCardDelete(t *testing.T) {
+	board := newEmptyBoard("b1")
+	board.Cards = []Card{{ID: "c1", Status: "ToDo"}, {ID: "c2", Status: "ToDo"}}
+
+	newBoard, err := applyCardDelete(board, "c1")
+	if err != nil {
+		t.Fatalf("applyCardDelete: %v", err)
+	}
+	if len(newBoard.Cards) != 1 || newBoard.Cards[0].ID != "c2" {
+		t.Fatalf("newBoard.Cards = %+v, want only c2", newBoard.Cards)
+	}
+	if len(board.Cards) != 2 {
+		t.Fatalf("original board.Cards mutated: %+v", board.Cards)
+	}
+}
+
+func TestApplyCardDeleteUnknownID(t *testing.T) {
+	board := newEmptyBoard("b1")
+	if _, err := applyCardDelete(board, "missing"); err != ErrCardNotFound {
+		t.Fatalf("err = %v, want ErrCardNotFound", err)
+	}
+}
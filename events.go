@@ -0,0 +1,191 @@
+package main
+
+import "sync"
+
+// EventType identifies what happened to a card in a published Event.
+type EventType string
+
+const (
+	EventCardCreated EventType = "card_created"
+	EventCardUpdated EventType = "card_updated"
+	EventCardDeleted EventType = "card_deleted"
+)
+
+// Event is pushed to a board's subscribers whenever a mutation succeeds, so
+// open clients can update without polling.
+type Event struct {
+	ID      int       `json:"id"`
+	BoardID string    `json:"board_id"`
+	Type    EventType `json:"type"`
+	Card    *Card     `json:"card,omitempty"`
+	CardID  string    `json:"card_id,omitempty"`
+}
+
+// eventRingSize bounds how many past events a board's EventBus entry keeps
+// for Last-Event-ID resume.
+const eventRingSize = 256
+
+// subscriberQueueSize is how many events a subscriber's channel buffers
+// before it's treated as a slow consumer and evicted.
+const subscriberQueueSize = 32
+
+// subscriber wraps a subscriber's channel with a mutex and closed flag, so
+// a send from Publish and a close from unsubscribe (which normally run on
+// different goroutines, e.g. a mutation handler racing a client disconnect)
+// can never overlap: closing a channel that a concurrent send is blocked on
+// panics, so every send and every close goes through subscriber.mu.
+type subscriber struct {
+	mu     sync.Mutex
+	ch     chan Event
+	closed bool
+}
+
+// send delivers evt if the subscriber isn't a slow consumer and isn't
+// already closed. It reports whether evt was (or could be) delivered;
+// false means the caller should retire this subscriber.
+func (s *subscriber) send(evt Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.ch <- evt:
+		return true
+	default:
+		s.closeLocked()
+		return false
+	}
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *subscriber) closeLocked() {
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// boardEvents holds the ring buffer and subscribers for a single board, so
+// traffic on one board never evicts or scrolls events out of range for a
+// subscriber watching a different one.
+type boardEvents struct {
+	ring        []Event
+	subscribers map[int]*subscriber
+}
+
+// EventBus fans Events out to any number of subscribers, each with its own
+// buffered channel, and keeps a per-board ring buffer of recent events so a
+// reconnecting client can resume via Last-Event-ID instead of missing
+// everything published while it was away.
+type EventBus struct {
+	mu        sync.Mutex
+	nextID    int
+	nextSubID int
+	boards    map[string]*boardEvents
+}
+
+// NewEventBus returns an empty EventBus ready to Publish/Subscribe.
+func NewEventBus() *EventBus {
+	return &EventBus{boards: make(map[string]*boardEvents)}
+}
+
+func (b *EventBus) boardEvents(boardID string) *boardEvents {
+	be, ok := b.boards[boardID]
+	if !ok {
+		be = &boardEvents{subscribers: make(map[int]*subscriber)}
+		b.boards[boardID] = be
+	}
+	return be
+}
+
+// Publish assigns evt the next event ID, records it in its board's ring
+// buffer, and delivers it to that board's current subscribers only. A
+// subscriber whose channel is full (a slow consumer) or has already
+// disconnected is retired rather than allowed to block Publish; subscribers
+// watching other boards are never affected.
+func (b *EventBus) Publish(evt Event) {
+	b.mu.Lock()
+	b.nextID++
+	evt.ID = b.nextID
+	be := b.boardEvents(evt.BoardID)
+	be.ring = append(be.ring, evt)
+	if len(be.ring) > eventRingSize {
+		be.ring = be.ring[len(be.ring)-eventRingSize:]
+	}
+	targets := make(map[int]*subscriber, len(be.subscribers))
+	for id, sub := range be.subscribers {
+		targets[id] = sub
+	}
+	b.mu.Unlock()
+
+	for id, sub := range targets {
+		if !sub.send(evt) {
+			b.unsubscribe(evt.BoardID, id)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber for boardID and returns the events it
+// missed since afterEventID (0 means "none", i.e. no resume), the channel
+// future events for boardID arrive on, and an unsubscribe function the
+// caller must call when done. Events published for other boards are never
+// enqueued on the returned channel.
+func (b *EventBus) Subscribe(boardID string, afterEventID int) (missed []Event, events <-chan Event, unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	be := b.boardEvents(boardID)
+
+	if afterEventID > 0 {
+		for _, evt := range be.ring {
+			if evt.ID > afterEventID {
+				missed = append(missed, evt)
+			}
+		}
+	}
+
+	b.nextSubID++
+	id := b.nextSubID
+	sub := &subscriber{ch: make(chan Event, subscriberQueueSize)}
+	be.subscribers[id] = sub
+
+	return missed, sub.ch, func() { b.unsubscribe(boardID, id) }
+}
+
+// Forget drops boardID's ring buffer if it currently has no subscribers, so
+// a deleted board's history doesn't occupy memory forever. A board that
+// still has clients watching it (e.g. one that gets recreated right after
+// being deleted) is left alone; those subscribers clean it up via
+// unsubscribe when they disconnect.
+func (b *EventBus) Forget(boardID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if be, ok := b.boards[boardID]; ok && len(be.subscribers) == 0 {
+		delete(b.boards, boardID)
+	}
+}
+
+// unsubscribe removes id from boardID's subscriber map and closes its
+// channel. The map removal happens under b.mu so it can't race a concurrent
+// Subscribe/Publish snapshot; the close itself happens under the
+// subscriber's own mutex (via sub.close), so it can never overlap a
+// concurrent sub.send from Publish.
+func (b *EventBus) unsubscribe(boardID string, id int) {
+	b.mu.Lock()
+	be, ok := b.boards[boardID]
+	var sub *subscriber
+	if ok {
+		sub, ok = be.subscribers[id]
+		delete(be.subscribers, id)
+	}
+	b.mu.Unlock()
+	if ok {
+		sub.close()
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestEventBusSubscribePublishUnsubscribeConcurrent(t *testing.T) {
+	bus := NewEventBus()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(2)
+
+		_, incoming, unsubscribe := bus.Subscribe("b1", 0)
+
+		go func() {
+			defer wg.Done()
+			// Drain until the channel closes (eviction) or we give up,
+			// so Publish's send doesn't block forever on a full buffer.
+			for range incoming {
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			unsubscribe()
+		}()
+
+		bus.Publish(Event{BoardID: "b1", Type: EventCardCreated})
+	}
+	wg.Wait()
+}
+
+func TestEventBusPublishFiltersByBoard(t *testing.T) {
+	bus := NewEventBus()
+	_, incomingA, unsubA := bus.Subscribe("a", 0)
+	defer unsubA()
+	_, incomingB, unsubB := bus.Subscribe("b", 0)
+	defer unsubB()
+
+	bus.Publish(Event{BoardID: "a", Type: EventCardCreated})
+
+	select {
+	case evt := <-incomingA:
+		if evt.BoardID != "a" {
+			t.Fatalf("evt.BoardID = %q, want a", evt.BoardID)
+		}
+	default:
+		t.Fatal("subscriber for board a received nothing")
+	}
+
+	select {
+	case evt := <-incomingB:
+		t.Fatalf("subscriber for board b received %+v, want nothing", evt)
+	default:
+	}
+}
+
+func TestEventBusSubscribeResumesFromRing(t *testing.T) {
+	bus := NewEventBus()
+	bus.Publish(Event{BoardID: "b1", Type: EventCardCreated, CardID: "c1"})
+	bus.Publish(Event{BoardID: "b1", Type: EventCardCreated, CardID: "c2"})
+
+	missed, _, unsubscribe := bus.Subscribe("b1", 1)
+	defer unsubscribe()
+
+	if len(missed) != 1 || missed[0].CardID != "c2" {
+		t.Fatalf("missed = %+v, want only the event after id 1", missed)
+	}
+}
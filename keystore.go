@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AccessKey is an S3-style access key/secret pair issued by "kban keygen",
+// scoped to one or more roles.
+type AccessKey struct {
+	AccessKeyID string    `json:"access_key_id"`
+	SecretKey   string    `json:"secret_key"`
+	Roles       []string  `json:"roles"` // any of "read", "write", "admin"
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// roleRank orders roles so a key can be checked against the minimum role a
+// request requires: admin > write > read.
+func roleRank(role string) int {
+	switch role {
+	case "read":
+		return 1
+	case "write":
+		return 2
+	case "admin":
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Authorizes reports whether the key's highest role meets or exceeds role.
+func (k AccessKey) Authorizes(role string) bool {
+	need := roleRank(role)
+	for _, r := range k.Roles {
+		if roleRank(r) >= need {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrKeyNotFound is returned by KeyStore.Get/Delete when accessKeyID doesn't
+// exist.
+var ErrKeyNotFound = errors.New("auth: access key not found")
+
+// KeyStore persists access keys. It mirrors BoardStore's driver-selection
+// pattern but keys don't need optimistic concurrency: key management is an
+// administrative, low-frequency operation.
+type KeyStore interface {
+	Get(ctx context.Context, accessKeyID string) (*AccessKey, error)
+	Save(ctx context.Context, key AccessKey) error
+	Delete(ctx context.Context, accessKeyID string) error
+	List(ctx context.Context) ([]AccessKey, error)
+}
+
+// KeyStoreFactory builds a KeyStore from the application configuration.
+type KeyStoreFactory func(cfg Config) (KeyStore, error)
+
+var (
+	keyDriversMu sync.RWMutex
+	keyDrivers   = make(map[string]KeyStoreFactory)
+)
+
+// RegisterKeyDriver makes a KeyStore driver available under name, selected
+// via the same storage.driver config field as BoardStore. It is meant to be
+// called from a driver's init() function.
+func RegisterKeyDriver(name string, factory KeyStoreFactory) {
+	keyDriversMu.Lock()
+	defer keyDriversMu.Unlock()
+	if factory == nil {
+		panic("auth: RegisterKeyDriver factory is nil")
+	}
+	if _, dup := keyDrivers[name]; dup {
+		panic("auth: RegisterKeyDriver called twice for driver " + name)
+	}
+	keyDrivers[name] = factory
+}
+
+// NewKeyStore builds the KeyStore selected by cfg.Storage.Driver.
+func NewKeyStore(cfg Config) (KeyStore, error) {
+	keyDriversMu.RLock()
+	factory, ok := keyDrivers[cfg.Storage.Driver]
+	keyDriversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+	return factory(cfg)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// filesystemKeyStore is the KeyStore backing one JSON file per access key,
+// under dir/keys/.
+type filesystemKeyStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func newFilesystemKeyStore(cfg Config) (KeyStore, error) {
+	dir := cfg.Storage.Filesystem.Path
+	if dir == "" {
+		return nil, errors.New("filesystem storage requires storage.filesystem.path")
+	}
+	keysDir := filepath.Join(dir, "keys")
+	if err := os.MkdirAll(keysDir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating keys directory: %w", err)
+	}
+	return &filesystemKeyStore{dir: dir}, nil
+}
+
+func (s *filesystemKeyStore) keyPath(accessKeyID string) string {
+	return filepath.Join(s.dir, "keys", accessKeyID+".json")
+}
+
+func (s *filesystemKeyStore) Get(ctx context.Context, accessKeyID string) (*AccessKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.keyPath(accessKeyID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("error reading access key: %w", err)
+	}
+	var key AccessKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("error decoding access key: %w", err)
+	}
+	return &key, nil
+}
+
+func (s *filesystemKeyStore) Save(ctx context.Context, key AccessKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("error encoding access key: %w", err)
+	}
+	if err := os.WriteFile(s.keyPath(key.AccessKeyID), data, 0o600); err != nil {
+		return fmt.Errorf("error writing access key: %w", err)
+	}
+	return nil
+}
+
+func (s *filesystemKeyStore) Delete(ctx context.Context, accessKeyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.keyPath(accessKeyID)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrKeyNotFound
+		}
+		return fmt.Errorf("error deleting access key: %w", err)
+	}
+	return nil
+}
+
+func (s *filesystemKeyStore) List(ctx context.Context) ([]AccessKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(filepath.Join(s.dir, "keys"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing keys directory: %w", err)
+	}
+	keys := make([]AccessKey, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, "keys", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading access key: %w", err)
+		}
+		var key AccessKey
+		if err := json.Unmarshal(data, &key); err != nil {
+			return nil, fmt.Errorf("error decoding access key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].AccessKeyID < keys[j].AccessKeyID })
+	return keys, nil
+}
+
+func init() {
+	RegisterKeyDriver("filesystem", newFilesystemKeyStore)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// memoryKeyStore is a KeyStore that keeps access keys in memory, for tests
+// and local development.
+type memoryKeyStore struct {
+	mu   sync.Mutex
+	keys map[string]AccessKey
+}
+
+func newMemoryKeyStore(cfg Config) (KeyStore, error) {
+	return &memoryKeyStore{keys: make(map[string]AccessKey)}, nil
+}
+
+func (s *memoryKeyStore) Get(ctx context.Context, accessKeyID string) (*AccessKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[accessKeyID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return &key, nil
+}
+
+func (s *memoryKeyStore) Save(ctx context.Context, key AccessKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.AccessKeyID] = key
+	return nil
+}
+
+func (s *memoryKeyStore) Delete(ctx context.Context, accessKeyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[accessKeyID]; !ok {
+		return ErrKeyNotFound
+	}
+	delete(s.keys, accessKeyID)
+	return nil
+}
+
+func (s *memoryKeyStore) List(ctx context.Context) ([]AccessKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]AccessKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].AccessKeyID < keys[j].AccessKeyID })
+	return keys, nil
+}
+
+func init() {
+	RegisterKeyDriver("memory", newMemoryKeyStore)
+}
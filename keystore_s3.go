@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+const keysPrefix = "keys/"
+
+// s3KeyStore is the KeyStore backing one object per access key, at
+// keys/<access-key-id>.json in an S3 bucket (or any S3-compatible service).
+type s3KeyStore struct {
+	client *s3.Client
+	cfg    S3Config
+}
+
+func newS3KeyStore(cfg Config) (KeyStore, error) {
+	client, err := NewS3Client(cfg.Storage.S3)
+	if err != nil {
+		return nil, err
+	}
+	return &s3KeyStore{client: client, cfg: cfg.Storage.S3}, nil
+}
+
+func (s *s3KeyStore) objectKey(accessKeyID string) string {
+	return keysPrefix + accessKeyID + ".json"
+}
+
+func (s *s3KeyStore) Get(ctx context.Context, accessKeyID string) (*AccessKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.objectKey(accessKeyID)),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NoSuchKey" || apiErr.ErrorCode() == "NotFound") {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("error loading access key from S3: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading access key data: %w", err)
+	}
+	var key AccessKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("error decoding access key json: %w", err)
+	}
+	return &key, nil
+}
+
+func (s *s3KeyStore) Save(ctx context.Context, key AccessKey) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("error encoding access key json: %w", err)
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.objectKey(key.AccessKeyID)),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("error saving access key to S3: %w", err)
+	}
+	return nil
+}
+
+func (s *s3KeyStore) Delete(ctx context.Context, accessKeyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := s.Get(ctx, accessKeyID); err != nil {
+		return err
+	}
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.objectKey(accessKeyID)),
+	})
+	return err
+}
+
+func (s *s3KeyStore) List(ctx context.Context) ([]AccessKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	ids := []string{}
+	var continuationToken *string
+	for {
+		resp, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(s.cfg.Bucket),
+			Prefix:            aws.String(keysPrefix),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range resp.Contents {
+			id := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(obj.Key), keysPrefix), ".json")
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if resp.IsTruncated == nil || !*resp.IsTruncated {
+			break
+		}
+		continuationToken = resp.NextContinuationToken
+	}
+	keys := make([]AccessKey, 0, len(ids))
+	for _, id := range ids {
+		key, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func init() {
+	RegisterKeyDriver("s3", newS3KeyStore)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -20,10 +25,35 @@ type S3Config struct {
 	SecretKey       string `yaml:"secret_key"`
 	UsePathStyle    bool   `yaml:"use_path_style"`
 	DisableChecksum bool   `yaml:"disable_checksum"`
+
+	// CredentialsProvider selects how AWS credentials are obtained: "static"
+	// (default, uses AccessKey/SecretKey above), "shared" (~/.aws/credentials
+	// profile), "env", "ec2_instance_profile", "ecs_task_role", or
+	// "web_identity" (IRSA).
+	CredentialsProvider  string `yaml:"credentials_provider"`
+	SharedProfile        string `yaml:"shared_profile"`          // used by "shared"
+	RoleARN              string `yaml:"role_arn"`                // used by "web_identity"
+	WebIdentityTokenFile string `yaml:"web_identity_token_file"` // used by "web_identity"
+
+	// SSE selects server-side encryption on PutObject: "" (none), "AES256",
+	// or "aws:kms". SSEKMSKeyID is only meaningful for "aws:kms".
+	SSE         string `yaml:"sse"`
+	SSEKMSKeyID string `yaml:"sse_kms_key_id"`
+
+	RetryMaxAttempts int    `yaml:"retry_max_attempts"`
+	RetryMaxBackoff  string `yaml:"retry_max_backoff"` // e.g. "20s"
+}
+
+// StorageConfig selects and configures the BoardStore driver.
+type StorageConfig struct {
+	Driver       string           `yaml:"driver"` // "s3", "filesystem", or "memory"
+	S3           S3Config         `yaml:"s3"`
+	Filesystem   FilesystemConfig `yaml:"filesystem"`
+	ListCacheTTL string           `yaml:"list_cache_ttl"` // e.g. "5s"; defaults to defaultListCacheTTL
 }
 
 type Config struct {
-	S3 S3Config `yaml:"s3"`
+	Storage StorageConfig `yaml:"storage"`
 }
 
 func loadConfig(path string) (*Config, error) {
@@ -41,22 +71,163 @@ func loadConfig(path string) (*Config, error) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "keygen" {
+		runKeygen(os.Args[2:])
+		return
+	}
+
 	cfg, err := loadConfig("config.yml")
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
-	// Initialiser S3
-	s3Client, err := NewS3Client(cfg.S3)
+	store, err := NewBoardStore(*cfg)
+	if err != nil {
+		log.Fatalf("Failed to init storage: %v", err)
+	}
+	keys, err := NewKeyStore(*cfg)
 	if err != nil {
-		log.Fatalf("Failed to init S3: %v", err)
+		log.Fatalf("Failed to init key storage: %v", err)
 	}
+	tokens := newTokenIssuer()
+	events := NewEventBus()
 
 	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(authMiddleware(keys, tokens))
+
+	// API: Issue a bearer token for the key that signed this request, so
+	// later calls can use "Authorization: Bearer <token>" instead of
+	// re-signing every request.
+	api.HandleFunc("/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		key, ok := accessKeyFromContext(r.Context())
+		if !ok {
+			w.WriteHeader(500)
+			return
+		}
+		token, err := tokens.issue(*key)
+		if err != nil {
+			log.Printf("Error issuing token: %v", err)
+			w.WriteHeader(500)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": token})
+	}).Methods("POST")
+
+	// API: List access keys (secrets withheld)
+	api.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		list, err := keys.List(r.Context())
+		if err != nil {
+			log.Printf("Error listing keys: %v", err)
+			w.WriteHeader(500)
+			return
+		}
+		for i := range list {
+			list[i].SecretKey = ""
+		}
+		json.NewEncoder(w).Encode(list)
+	}).Methods("GET")
+
+	// API: Create an access key
+	api.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Roles []string `json:"roles"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		key, err := generateAccessKey(req.Roles)
+		if err != nil {
+			log.Printf("Error generating access key: %v", err)
+			w.WriteHeader(500)
+			return
+		}
+		if err := keys.Save(r.Context(), key); err != nil {
+			log.Printf("Error saving access key: %v", err)
+			w.WriteHeader(500)
+			return
+		}
+		json.NewEncoder(w).Encode(key)
+	}).Methods("POST")
+
+	// API: Revoke an access key
+	api.HandleFunc("/keys/{accessKeyID}", func(w http.ResponseWriter, r *http.Request) {
+		accessKeyID := mux.Vars(r)["accessKeyID"]
+		if err := keys.Delete(r.Context(), accessKeyID); err != nil {
+			if errors.Is(err, ErrKeyNotFound) {
+				w.WriteHeader(404)
+				return
+			}
+			log.Printf("Error deleting access key: %v", err)
+			w.WriteHeader(500)
+			return
+		}
+		w.WriteHeader(204)
+	}).Methods("DELETE")
+
+	// API: List boards
+	api.HandleFunc("/boards", func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("[GET] /api/boards")
+		ids, err := store.List(r.Context())
+		if err != nil {
+			log.Printf("Error listing boards: %v", err)
+			w.WriteHeader(500)
+			return
+		}
+		json.NewEncoder(w).Encode(ids)
+	}).Methods("GET")
+
+	// API: Create board
+	api.HandleFunc("/boards", func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("[POST] /api/boards")
+		var req Board
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding board: %v", err)
+			w.WriteHeader(400)
+			return
+		}
+		board := newEmptyBoard(generateID())
+		board.Name = req.Name
+		if len(req.Columns) > 0 {
+			board.Columns = req.Columns
+		}
+		if _, err := store.Save(r.Context(), board.ID, board, noSuchBoardVersion); err != nil {
+			if errors.Is(err, ErrConflict) {
+				log.Printf("Board ID collision on create: %s", board.ID)
+				w.WriteHeader(409)
+				return
+			}
+			log.Printf("Error creating board: %v", err)
+			w.WriteHeader(500)
+			return
+		}
+		log.Printf("Board created: %s", board.ID)
+		json.NewEncoder(w).Encode(board)
+	}).Methods("POST")
+
+	// API: Delete board
+	api.HandleFunc("/boards/{boardID}", func(w http.ResponseWriter, r *http.Request) {
+		boardID := mux.Vars(r)["boardID"]
+		log.Printf("[DELETE] /api/boards/%s", boardID)
+		if err := store.Delete(r.Context(), boardID); err != nil {
+			if errors.Is(err, ErrBoardNotFound) {
+				w.WriteHeader(404)
+				return
+			}
+			log.Printf("Error deleting board %s: %v", boardID, err)
+			w.WriteHeader(500)
+			return
+		}
+		events.Forget(boardID)
+		log.Printf("Board deleted: %s", boardID)
+		w.WriteHeader(204)
+	}).Methods("DELETE")
 
 	// API: Get board
-	r.HandleFunc("/api/board", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("[GET] /api/board")
-		board, err := LoadBoard(s3Client, cfg.S3)
+	api.HandleFunc("/boards/{boardID}", func(w http.ResponseWriter, r *http.Request) {
+		boardID := mux.Vars(r)["boardID"]
+		log.Printf("[GET] /api/boards/%s", boardID)
+		board, _, err := store.Load(r.Context(), boardID)
 		if err != nil {
 			log.Printf("Error loading board: %v", err)
 			w.WriteHeader(500)
@@ -67,9 +238,13 @@ func main() {
 		json.NewEncoder(w).Encode(board)
 	}).Methods("GET")
 
+	// API: Live board updates (SSE)
+	api.HandleFunc("/boards/{boardID}/stream", streamBoardHandler(events)).Methods("GET")
+
 	// API: Create card
-	r.HandleFunc("/api/card", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("[POST] /api/card")
+	api.HandleFunc("/boards/{boardID}/card", func(w http.ResponseWriter, r *http.Request) {
+		boardID := mux.Vars(r)["boardID"]
+		log.Printf("[POST] /api/boards/%s/card", boardID)
 		var card Card
 		if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
 			log.Printf("Error decoding card: %v", err)
@@ -77,36 +252,28 @@ func main() {
 			return
 		}
 		log.Printf("Payload: %+v", card)
-		board, err := LoadBoard(s3Client, cfg.S3)
-		if err != nil {
-			log.Printf("Error loading board: %v", err)
-			w.WriteHeader(500)
-			return
-		}
 		card.ID = generateID()
-		// Position = dernier dans la colonne
-		maxPos := -1
-		for _, c := range board.Cards {
-			if c.Status == card.Status && c.Position > maxPos {
-				maxPos = c.Position
-			}
-		}
-		card.Position = maxPos + 1
-		board.Cards = append(board.Cards, card)
-		if err := SaveBoard(s3Client, cfg.S3, board); err != nil {
+		var created Card
+		_, err := saveWithRetry(r.Context(), store, boardID, func(board *Board) (*Board, error) {
+			newBoard, finalCard := applyCardCreate(board, card)
+			created = finalCard
+			return newBoard, nil
+		})
+		if err != nil {
 			log.Printf("Error saving board: %v", err)
-			w.WriteHeader(500)
+			writeMutationError(w, err)
 			return
 		}
-		log.Printf("Card created: %+v", card)
-		json.NewEncoder(w).Encode(card)
+		log.Printf("Card created: %+v", created)
+		events.Publish(Event{BoardID: boardID, Type: EventCardCreated, Card: &created})
+		json.NewEncoder(w).Encode(created)
 	}).Methods("POST")
 
 	// API: Update card (inclut position)
-	r.HandleFunc("/api/card/{id}", func(w http.ResponseWriter, r *http.Request) {
+	api.HandleFunc("/boards/{boardID}/card/{id}", func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
-		id := vars["id"]
-		log.Printf("[PUT] /api/card/%s", id)
+		boardID, id := vars["boardID"], vars["id"]
+		log.Printf("[PUT] /api/boards/%s/card/%s", boardID, id)
 		var update Card
 		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 			log.Printf("Error decoding update: %v", err)
@@ -114,118 +281,40 @@ func main() {
 			return
 		}
 		log.Printf("Payload: %+v", update)
-		board, err := LoadBoard(s3Client, cfg.S3)
-		if err != nil {
-			log.Printf("Error loading board: %v", err)
-			w.WriteHeader(500)
-			return
-		}
-		var oldStatus string
-		var oldPosition int
-		updated := false
-		for i, c := range board.Cards {
-			if c.ID == id {
-				oldStatus = c.Status
-				oldPosition = c.Position
-				board.Cards[i].Title = update.Title
-				board.Cards[i].Description = update.Description
-				board.Cards[i].Status = update.Status
-				if update.Position != 0 || update.Position == 0 {
-					board.Cards[i].Position = update.Position
-				}
-				updated = true
-				break
-			}
-		}
-		if !updated {
-			log.Printf("Card not found: %s", id)
-			w.WriteHeader(404)
-			return
-		}
-		// Réordonner les positions dans la colonne si la position a changé
-		if oldStatus != update.Status || oldPosition != update.Position {
-			// Find index of moving card in board
-			moveIdx := findCardIndex(board.Cards, id)
-			if moveIdx == -1 {
-				log.Printf("Moving card not found in board: %s", id)
-			} else {
-				// Build list of other cards in the target column (exclude moving card)
-				others := []Card{}
-				for _, cc := range board.Cards {
-					if cc.ID == id {
-						continue
-					}
-					if cc.Status == update.Status {
-						others = append(others, cc)
-					}
-				}
-				// Clamp requested position
-				pos := update.Position
-				if pos < 0 {
-					pos = 0
-				}
-				if pos > len(others) {
-					pos = len(others)
-				}
-				// Build new order for the column: insert moving card at pos
-				movingCard := board.Cards[moveIdx]
-				movingCard.Status = update.Status
-				newOrder := make([]Card, 0, len(others)+1)
-				newOrder = append(newOrder, others[:pos]...)
-				newOrder = append(newOrder, movingCard)
-				if pos < len(others) {
-					newOrder = append(newOrder, others[pos:]...)
-				}
-				// Reassign positions for cards in this column
-				for i, nc := range newOrder {
-					for j := range board.Cards {
-						if board.Cards[j].ID == nc.ID {
-							board.Cards[j].Position = i
-							board.Cards[j].Status = update.Status
-						}
-					}
-				}
+		var updated Card
+		_, err := saveWithRetry(r.Context(), store, boardID, func(board *Board) (*Board, error) {
+			newBoard, err := applyCardUpdate(board, id, update)
+			if err != nil {
+				return nil, err
 			}
-		}
-		if err := SaveBoard(s3Client, cfg.S3, board); err != nil {
-			log.Printf("Error saving board: %v", err)
-			w.WriteHeader(500)
+			updated = newBoard.Cards[findCardIndex(newBoard.Cards, id)]
+			return newBoard, nil
+		})
+		if err != nil {
+			log.Printf("Error updating card %s: %v", id, err)
+			writeMutationError(w, err)
 			return
 		}
 		log.Printf("Card updated: %s", id)
+		events.Publish(Event{BoardID: boardID, Type: EventCardUpdated, Card: &updated})
 		w.WriteHeader(204)
 	}).Methods("PUT")
 
 	// API: Delete card
-	r.HandleFunc("/api/card/{id}", func(w http.ResponseWriter, r *http.Request) {
+	api.HandleFunc("/boards/{boardID}/card/{id}", func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
-		id := vars["id"]
-		log.Printf("[DELETE] /api/card/%s", id)
-		board, err := LoadBoard(s3Client, cfg.S3)
+		boardID, id := vars["boardID"], vars["id"]
+		log.Printf("[DELETE] /api/boards/%s/card/%s", boardID, id)
+		_, err := saveWithRetry(r.Context(), store, boardID, func(board *Board) (*Board, error) {
+			return applyCardDelete(board, id)
+		})
 		if err != nil {
-			log.Printf("Error loading board: %v", err)
-			w.WriteHeader(500)
-			return
-		}
-		countBefore := len(board.Cards)
-		newCards := []Card{}
-		for _, c := range board.Cards {
-			if c.ID != id {
-				newCards = append(newCards, c)
-			}
-		}
-		if len(newCards) == countBefore {
-			log.Printf("Card not found for delete: %s", id)
-			w.WriteHeader(404)
-			return
-		}
-		board.Cards = newCards
-		if err := SaveBoard(s3Client, cfg.S3, board); err != nil {
-			log.Printf("Error saving board: %v", err)
-			w.WriteHeader(500)
+			log.Printf("Error deleting card %s: %v", id, err)
+			writeMutationError(w, err)
 			return
 		}
 		log.Printf("Card deleted: %s", id)
+		events.Publish(Event{BoardID: boardID, Type: EventCardDeleted, CardID: id})
 		w.WriteHeader(204)
 	}).Methods("DELETE")
 
@@ -236,11 +325,140 @@ func main() {
 	http.ListenAndServe(":8080", r)
 }
 
+// maxSaveAttempts bounds how many times saveWithRetry reloads the board and
+// replays a mutation after losing a race with another writer.
+const maxSaveAttempts = 5
+
+// ErrCardNotFound is returned by the pure card-mutation functions when the
+// requested card ID doesn't exist in the board.
+var ErrCardNotFound = errors.New("card not found")
+
+// saveWithRetry loads boardID, applies mutate to get the next board state,
+// and saves it conditioned on the version it just loaded. If another writer
+// saved in between (store.Save returns ErrConflict), it reloads and replays
+// mutate against the fresh snapshot, up to maxSaveAttempts times before
+// giving up with ErrConflict.
+func saveWithRetry(ctx context.Context, store BoardStore, boardID string, mutate func(*Board) (*Board, error)) (*Board, error) {
+	var lastErr error
+	for attempt := 0; attempt < maxSaveAttempts; attempt++ {
+		board, version, err := store.Load(ctx, boardID)
+		if err != nil {
+			return nil, err
+		}
+		newBoard, err := mutate(board)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := store.Save(ctx, boardID, newBoard, version); err != nil {
+			if errors.Is(err, ErrConflict) {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		return newBoard, nil
+	}
+	return nil, lastErr
+}
+
+// writeMutationError maps an error from saveWithRetry to the matching HTTP
+// status: 404 when the card doesn't exist, 409 when every retry lost the
+// race with another writer, 500 otherwise.
+func writeMutationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrCardNotFound):
+		w.WriteHeader(404)
+	case errors.Is(err, ErrConflict):
+		w.WriteHeader(409)
+		w.Write([]byte("Conflict: board changed concurrently, please retry"))
+	default:
+		w.WriteHeader(500)
+	}
+}
+
 func generateID() string {
 	// Génère un ID unique simple (à améliorer si besoin)
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
 
+// sseKeepAliveInterval is how often streamBoardHandler sends a comment line
+// to keep idle SSE connections (and any intermediate proxies) from timing
+// out.
+const sseKeepAliveInterval = 15 * time.Second
+
+// streamBoardHandler serves Server-Sent Events for one board: a live feed
+// of card create/update/delete events published by the mutation handlers
+// above. Clients that reconnect with a Last-Event-ID header resume from
+// bus's ring buffer instead of missing events published while disconnected.
+func streamBoardHandler(bus *EventBus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		boardID := mux.Vars(r)["boardID"]
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			w.WriteHeader(500)
+			return
+		}
+
+		lastEventID := 0
+		if v := r.Header.Get("Last-Event-ID"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				lastEventID = n
+			}
+		}
+
+		missed, incoming, unsubscribe := bus.Subscribe(boardID, lastEventID)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(200)
+
+		for _, evt := range missed {
+			if err := writeEvent(w, evt); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(sseKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case evt, ok := <-incoming:
+				if !ok {
+					// Evicted as a slow consumer.
+					return
+				}
+				if err := writeEvent(w, evt); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes evt to w in SSE wire format, with the event ID set so a
+// reconnecting client can resume via Last-Event-ID.
+func writeEvent(w http.ResponseWriter, evt Event) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", evt.ID, data)
+	return err
+}
+
 func findCardIndex(cards []Card, id string) int {
 	for i, c := range cards {
 		if c.ID == id {
@@ -249,3 +467,40 @@ func findCardIndex(cards []Card, id string) int {
 	}
 	return -1
 }
+
+// runKeygen implements "kban keygen", which issues a new access key and
+// persists it via the configured KeyStore, then prints it once to stdout.
+// The secret is stored server-side (KeyStore needs it in plaintext to
+// verify request signatures) but is never shown again via the API or CLI
+// after this one printout.
+func runKeygen(args []string) {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	rolesFlag := fs.String("roles", "read,write", "comma-separated roles to grant: read, write, admin")
+	configPath := fs.String("config", "config.yml", "path to config.yml")
+	fs.Parse(args)
+
+	var roles []string
+	for _, r := range strings.Split(*rolesFlag, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			roles = append(roles, r)
+		}
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	keys, err := NewKeyStore(*cfg)
+	if err != nil {
+		log.Fatalf("Failed to init key storage: %v", err)
+	}
+	key, err := generateAccessKey(roles)
+	if err != nil {
+		log.Fatalf("Failed to generate access key: %v", err)
+	}
+	if err := keys.Save(context.Background(), key); err != nil {
+		log.Fatalf("Failed to save access key: %v", err)
+	}
+	fmt.Printf("AccessKeyID: %s\nSecretKey:   %s\nRoles:       %s\n", key.AccessKeyID, key.SecretKey, strings.Join(key.Roles, ","))
+	fmt.Println("Store the secret key now — it will not be shown again.")
+}
@@ -4,10 +4,25 @@ type Card struct {
 	ID          string `json:"id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
-	Status      string `json:"status"` // ToDo, Doing, Hold, Done
+	Status      string `json:"status"` // one of the parent Board's Columns
 	Position    int    `json:"position"`
 }
 
 type Board struct {
-	Cards []Card `json:"cards"`
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Cards   []Card   `json:"cards"`
+}
+
+// DefaultColumns is used for a board created without an explicit column list.
+var DefaultColumns = []string{"ToDo", "Doing", "Hold", "Done"}
+
+// newEmptyBoard returns the board returned for an id that has no stored
+// content yet, e.g. right after it's created or when a driver finds nothing
+// at its key.
+func newEmptyBoard(id string) *Board {
+	columns := make([]string, len(DefaultColumns))
+	copy(columns, DefaultColumns)
+	return &Board{ID: id, Columns: columns, Cards: []Card{}}
 }
@@ -9,15 +9,28 @@ import (
 	"io/ioutil"
 	"log"
 	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/aws/smithy-go"
 )
 
+// probeKey is the object SaveBoard-style round trip used to validate an SSE
+// configuration at startup; it is written and immediately cleaned up, never
+// surfaced to callers.
+const probeKey = ".kban-sse-probe"
+
 // NewS3Client initializes an S3 client using the provided configuration.
 // It is compatible with MinIO and other S3-compatible services.
 func NewS3Client(cfg S3Config) (*s3.Client, error) {
@@ -30,15 +43,50 @@ func NewS3Client(cfg S3Config) (*s3.Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
 	}
-	awsCfg, err := config.LoadDefaultConfig(context.Background(),
+
+	opts := []func(*config.LoadOptions) error{
 		config.WithRegion(cfg.Region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
 		config.WithEndpointResolver(
 			aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
 				return aws.Endpoint{URL: endpoint, SigningRegion: cfg.Region}, nil
 			}),
 		),
-	)
+	}
+
+	if cfg.CredentialsProvider == "shared" {
+		profile := cfg.SharedProfile
+		if profile == "" {
+			profile = "default"
+		}
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	} else {
+		provider, err := resolveCredentialsProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, config.WithCredentialsProvider(provider))
+	}
+
+	switch {
+	case cfg.RetryMaxBackoff != "":
+		maxBackoff, err := time.ParseDuration(cfg.RetryMaxBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage.s3.retry_max_backoff: %w", err)
+		}
+		maxAttempts := cfg.RetryMaxAttempts
+		opts = append(opts, config.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				if maxAttempts > 0 {
+					o.MaxAttempts = maxAttempts
+				}
+				o.MaxBackoff = maxBackoff
+			})
+		}))
+	case cfg.RetryMaxAttempts > 0:
+		opts = append(opts, config.WithRetryMaxAttempts(cfg.RetryMaxAttempts))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -47,6 +95,91 @@ func NewS3Client(cfg S3Config) (*s3.Client, error) {
 	}), nil
 }
 
+// resolveCredentialsProvider selects the aws.CredentialsProvider named by
+// cfg.CredentialsProvider, mirroring the credential-chain options of the
+// AWS CLI/SDKs: static keys, environment variables, an EC2 instance
+// profile, an ECS task role, or a web identity (IRSA) token. "shared" is
+// handled by the caller via config.WithSharedConfigProfile instead, since
+// it isn't a single provider but a whole config source.
+func resolveCredentialsProvider(cfg S3Config) (aws.CredentialsProvider, error) {
+	switch cfg.CredentialsProvider {
+	case "", "static":
+		return credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, ""), nil
+	case "env":
+		return envCredentialsProvider{}, nil
+	case "ec2_instance_profile":
+		return ec2rolecreds.New(), nil
+	case "ecs_task_role":
+		endpoint, err := ecsTaskRoleEndpoint()
+		if err != nil {
+			return nil, err
+		}
+		return endpointcreds.New(endpoint), nil
+	case "web_identity":
+		return webIdentityCredentialsProvider(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage.s3.credentials_provider %q", cfg.CredentialsProvider)
+	}
+}
+
+// envCredentialsProvider reads static credentials from the standard AWS
+// environment variables, for deployments that inject them directly rather
+// than relying on the SDK's own default chain.
+type envCredentialsProvider struct{}
+
+func (envCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return aws.Credentials{}, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set for credentials_provider \"env\"")
+	}
+	return aws.Credentials{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Source:          "EnvConfigCredentials",
+	}, nil
+}
+
+// ecsTaskRoleEndpoint returns the container credentials endpoint ECS injects
+// into a task's environment, for endpointcreds to poll.
+func ecsTaskRoleEndpoint() (string, error) {
+	if full := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); full != "" {
+		return full, nil
+	}
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		return "http://169.254.170.2" + relative, nil
+	}
+	return "", errors.New("credentials_provider \"ecs_task_role\" requires AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI to be set")
+}
+
+// webIdentityCredentialsProvider builds the IRSA-style provider that
+// exchanges a projected service-account token for temporary credentials via
+// sts:AssumeRoleWithWebIdentity.
+func webIdentityCredentialsProvider(cfg S3Config) (aws.CredentialsProvider, error) {
+	roleARN := cfg.RoleARN
+	if roleARN == "" {
+		roleARN = os.Getenv("AWS_ROLE_ARN")
+	}
+	tokenFile := cfg.WebIdentityTokenFile
+	if tokenFile == "" {
+		tokenFile = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	if roleARN == "" || tokenFile == "" {
+		return nil, errors.New("credentials_provider \"web_identity\" requires role_arn and web_identity_token_file (or AWS_ROLE_ARN/AWS_WEB_IDENTITY_TOKEN_FILE)")
+	}
+	stsCfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config for web identity credentials: %w", err)
+	}
+	stsClient := sts.NewFromConfig(stsCfg)
+	return stscreds.NewWebIdentityRoleProvider(stsClient, roleARN, stscreds.IdentityTokenFile(tokenFile)), nil
+}
+
+// EnsureBucketExists confirms the configured bucket is reachable, and, when
+// server-side encryption is configured, performs a round-trip PutObject and
+// HeadObject on a throwaway key so a misconfigured or inaccessible KMS key
+// is caught at startup rather than on the first real save.
 func EnsureBucketExists(s3Client *s3.Client, cfg S3Config) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -60,51 +193,137 @@ func EnsureBucketExists(s3Client *s3.Client, cfg S3Config) error {
 		}
 		return fmt.Errorf("error checking bucket: %w", err)
 	}
+
+	if cfg.SSE == "" {
+		return nil
+	}
+	return probeSSE(ctx, s3Client, cfg)
+}
+
+// probeSSE writes and reads back a throwaway object with the configured SSE
+// settings applied, to confirm the KMS key (if any) is usable before the
+// server starts accepting traffic.
+func probeSSE(ctx context.Context, s3Client *s3.Client, cfg S3Config) error {
+	putInput := &s3.PutObjectInput{
+		Bucket: aws.String(cfg.Bucket),
+		Key:    aws.String(probeKey),
+		Body:   bytes.NewReader([]byte("kban sse probe")),
+	}
+	applySSE(putInput, cfg)
+	if _, err := s3Client.PutObject(ctx, putInput); err != nil {
+		return fmt.Errorf("error validating SSE (%s) with a probe PutObject: %w", cfg.SSE, err)
+	}
+	if _, err := s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(cfg.Bucket),
+		Key:    aws.String(probeKey),
+	}); err != nil {
+		return fmt.Errorf("error validating SSE (%s) with a probe HeadObject: %w", cfg.SSE, err)
+	}
+	if _, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(cfg.Bucket),
+		Key:    aws.String(probeKey),
+	}); err != nil {
+		return fmt.Errorf("error cleaning up SSE probe object: %w", err)
+	}
 	return nil
 }
 
-func LoadBoard(s3Client *s3.Client, cfg S3Config) (*Board, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// applySSE sets the ServerSideEncryption (and SSEKMSKeyId, when using KMS)
+// fields on input according to cfg.SSE.
+func applySSE(input *s3.PutObjectInput, cfg S3Config) {
+	switch cfg.SSE {
+	case "AES256":
+		input.ServerSideEncryption = types.ServerSideEncryptionAes256
+	case "aws:kms":
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		if cfg.SSEKMSKeyID != "" {
+			input.SSEKMSKeyId = aws.String(cfg.SSEKMSKeyID)
+		}
+	}
+}
+
+// LoadBoard returns the board stored at key, along with its S3 ETag (the
+// VersionId too, when the bucket has versioning enabled) so callers can
+// pass it back into SaveBoard as an optimistic-concurrency precondition.
+// The returned version is empty when the object does not exist yet.
+func LoadBoard(ctx context.Context, s3Client *s3.Client, cfg S3Config, key string) (*Board, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	resp, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: &cfg.Bucket,
-		Key:    aws.String("board.json"),
+		Key:    aws.String(key),
 	})
 	if err != nil {
 		var apiErr smithy.APIError
 		if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NoSuchKey" || apiErr.ErrorCode() == "NotFound") {
-			log.Printf("board.json not found on S3, returning empty board")
-			return &Board{Cards: []Card{}}, nil
+			log.Printf("%s not found on S3, returning empty board", key)
+			return &Board{Cards: []Card{}}, "", nil
 		}
-		return nil, fmt.Errorf("error loading board from S3: %w", err)
+		return nil, "", fmt.Errorf("error loading board from S3: %w", err)
 	}
 	defer resp.Body.Close()
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading board data: %w", err)
+		return nil, "", fmt.Errorf("error reading board data: %w", err)
 	}
 	var board Board
 	if err := json.Unmarshal(data, &board); err != nil {
-		return nil, fmt.Errorf("error decoding board json: %w", err)
+		return nil, "", fmt.Errorf("error decoding board json: %w", err)
+	}
+	version := aws.ToString(resp.ETag)
+	if resp.VersionId != nil {
+		version = aws.ToString(resp.VersionId) + ":" + version
 	}
-	return &board, nil
+	return &board, version, nil
 }
 
-func SaveBoard(s3Client *s3.Client, cfg S3Config, board *Board) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// SaveBoard writes board to key, returning its new ETag-based version. When
+// expectedVersion is noSuchBoardVersion, the write is conditioned on key not
+// existing yet (via an IfNoneMatch: "*" precondition), so two concurrent
+// first writes can't both succeed. When expectedVersion is any other
+// non-empty value, the write is conditioned on the object's current ETag
+// still matching it (via an IfMatch precondition). Either way, a concurrent
+// writer racing us causes S3 to reject the request with ErrConflict instead
+// of silently clobbering the other change.
+func SaveBoard(ctx context.Context, s3Client *s3.Client, cfg S3Config, key string, board *Board, expectedVersion string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	data, err := json.Marshal(board)
 	if err != nil {
-		return fmt.Errorf("error encoding board json: %w", err)
+		return "", fmt.Errorf("error encoding board json: %w", err)
 	}
-	reader := bytes.NewReader(data)
-	_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket: &cfg.Bucket,
-		Key:    aws.String("board.json"),
-		Body:   reader,
-	})
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}
+	switch {
+	case expectedVersion == noSuchBoardVersion:
+		input.IfNoneMatch = aws.String("*")
+	case etagFromVersion(expectedVersion) != "":
+		input.IfMatch = aws.String(etagFromVersion(expectedVersion))
+	}
+	applySSE(input, cfg)
+	resp, err := s3Client.PutObject(ctx, input)
 	if err != nil {
-		return fmt.Errorf("error saving board to S3: %w", err)
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+			return "", ErrConflict
+		}
+		return "", fmt.Errorf("error saving board to S3: %w", err)
 	}
-	return nil
+	version := aws.ToString(resp.ETag)
+	if resp.VersionId != nil {
+		version = aws.ToString(resp.VersionId) + ":" + version
+	}
+	return version, nil
+}
+
+// etagFromVersion extracts the ETag portion of a version token produced by
+// LoadBoard/SaveBoard, stripping the optional "versionId:" prefix.
+func etagFromVersion(version string) string {
+	if i := strings.LastIndex(version, ":"); i != -1 {
+		return version[i+1:]
+	}
+	return version
 }
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultListCacheTTL is used when StorageConfig.ListCacheTTL is unset.
+const defaultListCacheTTL = 5 * time.Second
+
+// ErrConflict is returned by BoardStore.Save when expectedVersion no longer
+// matches what is stored, i.e. another writer saved in between our Load and
+// our Save.
+var ErrConflict = errors.New("storage: version conflict")
+
+// ErrBoardNotFound is returned by BoardStore.Delete when boardID doesn't
+// exist.
+var ErrBoardNotFound = errors.New("storage: board not found")
+
+// noSuchBoardVersion is the version token Load returns in place of a board
+// that doesn't exist yet. It is distinct from "" (which Save treats as "no
+// precondition, write unconditionally") so that saveWithRetry's first write
+// for a brand-new board still carries a precondition: Save rejects it with
+// ErrConflict if some other writer created the board in the meantime,
+// instead of letting two concurrent "create" requests race silently.
+const noSuchBoardVersion = "\x00absent"
+
+// BoardStore abstracts persistence for boards so handlers don't depend on
+// any particular backend (S3, local disk, in-memory, ...). A single store
+// holds any number of boards, each identified by boardID.
+type BoardStore interface {
+	// Load returns the board identified by boardID and an opaque version
+	// token identifying the exact content read, or a fresh empty board
+	// and noSuchBoardVersion if none exists yet.
+	Load(ctx context.Context, boardID string) (*Board, string, error)
+	// Save persists board under boardID. When expectedVersion is
+	// non-empty, the write only succeeds if it still matches the stored
+	// board's state: noSuchBoardVersion requires that boardID still not
+	// exist, any other value requires the stored version to still equal
+	// it. Otherwise it returns ErrConflict and leaves the stored board
+	// untouched. On success it returns the new version.
+	Save(ctx context.Context, boardID string, board *Board, expectedVersion string) (string, error)
+	// Delete removes the board identified by boardID, or returns
+	// ErrBoardNotFound if it doesn't exist.
+	Delete(ctx context.Context, boardID string) error
+	// List enumerates the IDs of the boards known to this store.
+	List(ctx context.Context) ([]string, error)
+}
+
+// versionCheck reports whether expectedVersion's precondition is satisfied,
+// given whether boardID currently has any stored content (exists) and, if
+// so, its currentVersion. Shared by every BoardStore driver's Save so the
+// three precondition cases (no precondition, must-not-exist, must-match)
+// are handled identically everywhere.
+func versionCheck(expectedVersion, currentVersion string, exists bool) bool {
+	switch expectedVersion {
+	case "":
+		return true
+	case noSuchBoardVersion:
+		return !exists
+	default:
+		return exists && expectedVersion == currentVersion
+	}
+}
+
+// StoreFactory builds a BoardStore from the application configuration.
+type StoreFactory func(cfg Config) (BoardStore, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]StoreFactory)
+)
+
+// RegisterDriver makes a storage driver available under name, so it can be
+// selected via the storage.driver config field. It is meant to be called
+// from a driver's init() function, following the same pattern as
+// database/sql drivers.
+func RegisterDriver(name string, factory StoreFactory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if factory == nil {
+		panic("storage: RegisterDriver factory is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("storage: RegisterDriver called twice for driver " + name)
+	}
+	drivers[name] = factory
+}
+
+// NewBoardStore builds the BoardStore selected by cfg.Storage.Driver,
+// wrapping it with a short-lived cache for List so a burst of
+// GET /api/boards requests doesn't hit the backend once per request.
+func NewBoardStore(cfg Config) (BoardStore, error) {
+	driversMu.RLock()
+	factory, ok := drivers[cfg.Storage.Driver]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+	store, err := factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ttl := defaultListCacheTTL
+	if cfg.Storage.ListCacheTTL != "" {
+		parsed, err := time.ParseDuration(cfg.Storage.ListCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage.list_cache_ttl: %w", err)
+		}
+		ttl = parsed
+	}
+	return &cachingBoardStore{BoardStore: store, ttl: ttl}, nil
+}
+
+// cachingBoardStore wraps a BoardStore and caches the result of List for
+// ttl, so enumerating boards doesn't round-trip to the backend on every
+// request. The cache is invalidated as soon as a board is saved or deleted
+// through this store.
+type cachingBoardStore struct {
+	BoardStore
+	ttl time.Duration
+
+	mu       sync.Mutex
+	cached   []string
+	cachedAt time.Time
+}
+
+func (c *cachingBoardStore) List(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != nil && time.Since(c.cachedAt) < c.ttl {
+		return c.cached, nil
+	}
+	ids, err := c.BoardStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.cached = ids
+	c.cachedAt = time.Now()
+	return ids, nil
+}
+
+func (c *cachingBoardStore) Save(ctx context.Context, boardID string, board *Board, expectedVersion string) (string, error) {
+	version, err := c.BoardStore.Save(ctx, boardID, board, expectedVersion)
+	if err == nil {
+		c.mu.Lock()
+		c.cached = nil
+		c.mu.Unlock()
+	}
+	return version, err
+}
+
+func (c *cachingBoardStore) Delete(ctx context.Context, boardID string) error {
+	err := c.BoardStore.Delete(ctx, boardID)
+	if err == nil {
+		c.mu.Lock()
+		c.cached = nil
+		c.mu.Unlock()
+	}
+	return err
+}
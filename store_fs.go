@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FilesystemConfig configures the local filesystem storage driver.
+type FilesystemConfig struct {
+	Path string `yaml:"path"` // directory holding one <board-id>.json file per board
+}
+
+// filesystemStore is the BoardStore backing one JSON file per board, under
+// dir/boards/. Writes are atomic: the new content is written to a temp file
+// in the same directory and then renamed over the target, so a crash or
+// concurrent read never observes a partially-written file. The version
+// token is the SHA-256 of the file's current content, checked under mu so
+// two in-process writers can't both pass the precondition check at once.
+type filesystemStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func newFilesystemStore(cfg Config) (BoardStore, error) {
+	dir := cfg.Storage.Filesystem.Path
+	if dir == "" {
+		return nil, errors.New("filesystem storage requires storage.filesystem.path")
+	}
+	boardsDir := filepath.Join(dir, "boards")
+	if err := os.MkdirAll(boardsDir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating storage directory: %w", err)
+	}
+	return &filesystemStore{dir: dir}, nil
+}
+
+func (s *filesystemStore) boardPath(boardID string) string {
+	return filepath.Join(s.dir, "boards", boardID+".json")
+}
+
+// readVersioned loads the raw file content and its SHA-256 version token.
+// It returns (nil, "", nil) when the file does not exist.
+func (s *filesystemStore) readVersioned(boardID string) ([]byte, string, error) {
+	data, err := os.ReadFile(s.boardPath(boardID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("error reading board file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
+
+func (s *filesystemStore) Load(ctx context.Context, boardID string) (*Board, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, version, err := s.readVersioned(boardID)
+	if err != nil {
+		return nil, "", err
+	}
+	if data == nil {
+		return newEmptyBoard(boardID), noSuchBoardVersion, nil
+	}
+	var board Board
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, "", fmt.Errorf("error decoding board json: %w", err)
+	}
+	return &board, version, nil
+}
+
+func (s *filesystemStore) Save(ctx context.Context, boardID string, board *Board, expectedVersion string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if expectedVersion != "" {
+		data, currentVersion, err := s.readVersioned(boardID)
+		if err != nil {
+			return "", err
+		}
+		if !versionCheck(expectedVersion, currentVersion, data != nil) {
+			return "", ErrConflict
+		}
+	}
+	data, err := json.Marshal(board)
+	if err != nil {
+		return "", fmt.Errorf("error encoding board json: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Join(s.dir, "boards"), ".board-*.json.tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error writing temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error closing temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.boardPath(boardID)); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error renaming temp file into place: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (s *filesystemStore) Delete(ctx context.Context, boardID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.boardPath(boardID)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrBoardNotFound
+		}
+		return fmt.Errorf("error deleting board file: %w", err)
+	}
+	return nil
+}
+
+func (s *filesystemStore) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "boards"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing boards directory: %w", err)
+	}
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func init() {
+	RegisterDriver("filesystem", newFilesystemStore)
+}
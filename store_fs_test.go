@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func newTestFilesystemStore(t *testing.T) BoardStore {
+	t.Helper()
+	store, err := newFilesystemStore(Config{Storage: StorageConfig{Filesystem: FilesystemConfig{Path: t.TempDir()}}})
+	if err != nil {
+		t.Fatalf("newFilesystemStore: %v", err)
+	}
+	return store
+}
+
+func TestFilesystemStoreLoadMissingBoard(t *testing.T) {
+	store := newTestFilesystemStore(t)
+	board, version, err := store.Load(context.Background(), "missing")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if version != noSuchBoardVersion {
+		t.Fatalf("version = %q, want noSuchBoardVersion", version)
+	}
+	if board.ID != "missing" || len(board.Cards) != 0 {
+		t.Fatalf("board = %+v, want fresh empty board", board)
+	}
+}
+
+func TestFilesystemStoreSaveAndLoadRoundTrip(t *testing.T) {
+	store := newTestFilesystemStore(t)
+	ctx := context.Background()
+
+	board := newEmptyBoard("b1")
+	board.Cards = append(board.Cards, Card{ID: "c1", Status: "ToDo"})
+	version, err := store.Save(ctx, "b1", board, noSuchBoardVersion)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, loadedVersion, err := store.Load(ctx, "b1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loadedVersion != version {
+		t.Fatalf("loadedVersion = %q, want %q", loadedVersion, version)
+	}
+	if len(loaded.Cards) != 1 || loaded.Cards[0].ID != "c1" {
+		t.Fatalf("loaded.Cards = %+v", loaded.Cards)
+	}
+}
+
+func TestFilesystemStoreSaveRejectsConcurrentCreate(t *testing.T) {
+	store := newTestFilesystemStore(t)
+	ctx := context.Background()
+	board := newEmptyBoard("b1")
+
+	if _, err := store.Save(ctx, "b1", board, noSuchBoardVersion); err != nil {
+		t.Fatalf("first create: %v", err)
+	}
+	if _, err := store.Save(ctx, "b1", board, noSuchBoardVersion); err != ErrConflict {
+		t.Fatalf("second create: err = %v, want ErrConflict", err)
+	}
+}
+
+func TestFilesystemStoreSaveRejectsStaleVersion(t *testing.T) {
+	store := newTestFilesystemStore(t)
+	ctx := context.Background()
+
+	board := newEmptyBoard("b1")
+	version, err := store.Save(ctx, "b1", board, noSuchBoardVersion)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// A second writer saves a change from the same snapshot.
+	changed := *board
+	changed.Cards = append([]Card{}, Card{ID: "c1", Status: "ToDo"})
+	if _, err := store.Save(ctx, "b1", &changed, version); err != nil {
+		t.Fatalf("Save (second writer): %v", err)
+	}
+
+	// The first writer's retry with the now-stale version must conflict.
+	if _, err := store.Save(ctx, "b1", board, version); err != ErrConflict {
+		t.Fatalf("Save with stale version: err = %v, want ErrConflict", err)
+	}
+}
+
+func TestFilesystemStoreDeleteAndList(t *testing.T) {
+	store := newTestFilesystemStore(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"b2", "b1"} {
+		if _, err := store.Save(ctx, id, newEmptyBoard(id), noSuchBoardVersion); err != nil {
+			t.Fatalf("Save %s: %v", id, err)
+		}
+	}
+
+	ids, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "b1" || ids[1] != "b2" {
+		t.Fatalf("List = %v, want sorted [b1 b2]", ids)
+	}
+
+	if err := store.Delete(ctx, "b1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "b1"); err != ErrBoardNotFound {
+		t.Fatalf("Delete missing board: err = %v, want ErrBoardNotFound", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// memEntry is one board's state inside memoryStore.
+type memEntry struct {
+	board   *Board
+	version int
+}
+
+// memoryStore is a BoardStore that keeps boards in memory. It is meant for
+// tests and local development, where no external backend is available. Each
+// board's version token is a monotonically increasing revision counter.
+type memoryStore struct {
+	mu     sync.Mutex
+	boards map[string]*memEntry
+}
+
+func newMemoryStore(cfg Config) (BoardStore, error) {
+	return &memoryStore{boards: make(map[string]*memEntry)}, nil
+}
+
+func (s *memoryStore) Load(ctx context.Context, boardID string) (*Board, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.boards[boardID]
+	if !ok {
+		return newEmptyBoard(boardID), noSuchBoardVersion, nil
+	}
+	cards := make([]Card, len(entry.board.Cards))
+	copy(cards, entry.board.Cards)
+	board := *entry.board
+	board.Cards = cards
+	return &board, strconv.Itoa(entry.version), nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, boardID string, board *Board, expectedVersion string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.boards[boardID]
+	currentVersion := ""
+	if ok {
+		currentVersion = strconv.Itoa(entry.version)
+	}
+	if !versionCheck(expectedVersion, currentVersion, ok) {
+		return "", ErrConflict
+	}
+	cards := make([]Card, len(board.Cards))
+	copy(cards, board.Cards)
+	stored := *board
+	stored.Cards = cards
+	version := 1
+	if ok {
+		version = entry.version + 1
+	}
+	s.boards[boardID] = &memEntry{board: &stored, version: version}
+	return strconv.Itoa(version), nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, boardID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.boards[boardID]; !ok {
+		return ErrBoardNotFound
+	}
+	delete(s.boards, boardID)
+	return nil
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.boards))
+	for id := range s.boards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func init() {
+	RegisterDriver("memory", newMemoryStore)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+const boardsPrefix = "boards/"
+
+// s3Store is the BoardStore backing one object per board, at
+// boards/<board-id>.json in an S3 bucket (or any S3-compatible service
+// such as MinIO).
+type s3Store struct {
+	client *s3.Client
+	cfg    S3Config
+}
+
+func newS3Store(cfg Config) (BoardStore, error) {
+	client, err := NewS3Client(cfg.Storage.S3)
+	if err != nil {
+		return nil, err
+	}
+	if err := EnsureBucketExists(client, cfg.Storage.S3); err != nil {
+		return nil, err
+	}
+	return &s3Store{client: client, cfg: cfg.Storage.S3}, nil
+}
+
+func (s *s3Store) key(boardID string) string {
+	return boardsPrefix + boardID + ".json"
+}
+
+func (s *s3Store) Load(ctx context.Context, boardID string) (*Board, string, error) {
+	board, version, err := LoadBoard(ctx, s.client, s.cfg, s.key(boardID))
+	if err != nil {
+		return nil, "", err
+	}
+	if version == "" {
+		return newEmptyBoard(boardID), noSuchBoardVersion, nil
+	}
+	return board, version, nil
+}
+
+func (s *s3Store) Save(ctx context.Context, boardID string, board *Board, expectedVersion string) (string, error) {
+	return SaveBoard(ctx, s.client, s.cfg, s.key(boardID), board, expectedVersion)
+}
+
+func (s *s3Store) Delete(ctx context.Context, boardID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.key(boardID)),
+	}); err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NotFound" || apiErr.ErrorCode() == "NoSuchKey") {
+			return ErrBoardNotFound
+		}
+		return err
+	}
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.key(boardID)),
+	})
+	return err
+}
+
+func (s *s3Store) List(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	ids := []string{}
+	var continuationToken *string
+	for {
+		resp, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(s.cfg.Bucket),
+			Prefix:            aws.String(boardsPrefix),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range resp.Contents {
+			key := aws.ToString(obj.Key)
+			key = strings.TrimPrefix(key, boardsPrefix)
+			key = strings.TrimSuffix(key, ".json")
+			if key != "" {
+				ids = append(ids, key)
+			}
+		}
+		if resp.IsTruncated == nil || !*resp.IsTruncated {
+			break
+		}
+		continuationToken = resp.NextContinuationToken
+	}
+	return ids, nil
+}
+
+func init() {
+	RegisterDriver("s3", newS3Store)
+}